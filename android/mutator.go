@@ -15,12 +15,21 @@
 package android
 
 import (
+	"fmt"
+	"math"
 	"reflect"
+	"strings"
 
 	"github.com/google/blueprint"
 	"github.com/google/blueprint/proptools"
 )
 
+// hookContextPass is the pass number hookContext reads providers at.  It must be greater than
+// any pass index registerMutatorsContext.BottomUp/TopDown ever hands out (those are just
+// positions in mctx.mutators, so at most a few dozen even for the whole build), since
+// BeforePrepareBuildActions hooks run strictly after every mutator pass.
+const hookContextPass = math.MaxInt32
+
 // Phases:
 //   run Pre-arch mutators
 //   run archMutator
@@ -30,13 +39,41 @@ import (
 //   run FinalDeps mutators (CreateVariations disallowed in this phase)
 //   continue on to GenerateAndroidBuildActions
 
-func registerMutatorsToContext(ctx *blueprint.Context, mutators []*mutator) {
+// wrapBottomUpForMetrics brackets a bottom-up mutator function with BeginEvent/EndEvent calls on
+// config's EventHandler, the same pattern Blueprint's own bootstrap uses, and counts each module
+// it runs on in recorder - the EventHandler only knows about named event spans, not
+// Soong-specific counters.  Variations created and dependencies added are counted separately, by
+// the bottomUpMutatorContext methods that create them.
+func wrapBottomUpForMetrics(name string, config Config, recorder *mutatorMetricsRecorder, f blueprint.BottomUpMutator) blueprint.BottomUpMutator {
+	eventName := mutatorEventName(name)
+	return func(ctx blueprint.BottomUpMutatorContext) {
+		config.EventHandler().Begin(eventName)
+		defer config.EventHandler().End(eventName)
+		f(ctx)
+		recorder.record(name, 1, 0, 0)
+	}
+}
+
+// wrapTopDownForMetrics is the TopDownMutator equivalent of wrapBottomUpForMetrics.
+func wrapTopDownForMetrics(name string, config Config, recorder *mutatorMetricsRecorder, f blueprint.TopDownMutator) blueprint.TopDownMutator {
+	eventName := mutatorEventName(name)
+	return func(ctx blueprint.TopDownMutatorContext) {
+		config.EventHandler().Begin(eventName)
+		defer config.EventHandler().End(eventName)
+		f(ctx)
+		recorder.record(name, 1, 0, 0)
+	}
+}
+
+func registerMutatorsToContext(ctx *blueprint.Context, config Config, recorder *mutatorMetricsRecorder, mutators []*mutator) {
 	for _, t := range mutators {
 		var handle blueprint.MutatorHandle
 		if t.bottomUpMutator != nil {
-			handle = ctx.RegisterBottomUpMutator(t.name, t.bottomUpMutator)
+			handle = ctx.RegisterBottomUpMutator(t.name, wrapBottomUpForMetrics(t.name, config, recorder, t.bottomUpMutator))
 		} else if t.topDownMutator != nil {
-			handle = ctx.RegisterTopDownMutator(t.name, t.topDownMutator)
+			handle = ctx.RegisterTopDownMutator(t.name, wrapTopDownForMetrics(t.name, config, recorder, t.topDownMutator))
+		} else if t.transitionMutator != nil {
+			handle = ctx.RegisterTransitionMutator(t.name, t.transitionMutator)
 		}
 		if t.parallel {
 			handle.Parallel()
@@ -44,8 +81,12 @@ func registerMutatorsToContext(ctx *blueprint.Context, mutators []*mutator) {
 	}
 }
 
-func registerMutators(ctx *blueprint.Context, preArch, preDeps, postDeps, finalDeps []RegisterMutatorFunc) {
-	mctx := &registerMutatorsContext{}
+func registerMutators(ctx *blueprint.Context, config Config, preArch, preDeps, postDeps, finalDeps []RegisterMutatorFunc) {
+	resetTransitiveDepsCache()
+	resetDependencyTagTypeRegistry()
+
+	metrics := newMutatorMetricsRecorder()
+	mctx := &registerMutatorsContext{metrics: metrics}
 
 	register := func(funcs []RegisterMutatorFunc) {
 		for _, f := range funcs {
@@ -64,18 +105,55 @@ func registerMutators(ctx *blueprint.Context, preArch, preDeps, postDeps, finalD
 	mctx.finalPhase = true
 	register(finalDeps)
 
-	registerMutatorsToContext(ctx, mctx.mutators)
+	registerMutatorsToContext(ctx, config, metrics, mctx.mutators)
+
+	// Stash the recorder on Config so MutatorStats can reach it once the mutator phase has
+	// finished running.
+	config.setMutatorMetricsRecorder(metrics)
+
+	ctx.BeforePrepareBuildActionsHook(func() error {
+		// This is the first point after registration where every mutator has actually run, so
+		// config.EventHandler()'s cumulative "mutator.<name>" times are final.
+		logSlowMutators(config)
+		if path := config.config.mutatorTracePath; path != "" {
+			if err := WriteMutatorTrace(config, path); err != nil {
+				return err
+			}
+		}
+		return runBeforePrepareBuildActionsHooks(ctx, metrics)
+	})
 }
 
 type registerMutatorsContext struct {
 	mutators   []*mutator
 	finalPhase bool
+	metrics    *mutatorMetricsRecorder
 }
 
 type RegisterMutatorsContext interface {
 	TopDown(name string, m TopDownMutator) MutatorHandle
 	BottomUp(name string, m BottomUpMutator) MutatorHandle
 	BottomUpBlueprint(name string, m blueprint.BottomUpMutator) MutatorHandle
+
+	// Transition registers a TransitionMutator, which splits each module into the variations
+	// returned by its Split method, and then resolves each dependency edge to one of those
+	// variations by calling OutgoingTransition on the module that added the dependency followed
+	// by IncomingTransition on the dependency itself.  This replaces the CreateVariations +
+	// SetDependencyVariation / SetDefaultDependencyVariation pattern, where the module adding a
+	// dependency has to guess which variation the dependency wants, with one where the
+	// dependency always gets the final say.
+	Transition(name string, m TransitionMutator) MutatorHandle
+
+	// DeclareProvider marks key as produced by the mutator most recently registered on this
+	// context (the one returned by the TopDown/BottomUp/BottomUpBlueprint/Transition call
+	// immediately before this one), at the pass that mutator will run at. This records key's
+	// "sealed after" pass at registration time, before the producing mutator has run even once,
+	// so Provider/HasProvider can tell "read too early" apart from "the producing mutator ran but
+	// had nothing to set for this module" - which a lazily-recorded pass, set on the producing
+	// mutator's first actual SetProvider call, cannot: if that mutator never calls SetProvider for
+	// any module in a given build, the lazy pass is never recorded and every later read panics
+	// forever instead of legitimately reporting HasProvider == false.
+	DeclareProvider(key ProviderKey)
 }
 
 type RegisterMutatorFunc func(RegisterMutatorsContext)
@@ -163,22 +241,153 @@ var postDeps = []RegisterMutatorFunc{
 
 var finalDeps = []RegisterMutatorFunc{}
 
+// mutatorsFinalized is set by FinalizeMutators once registerMutators has scheduled the
+// before-prepare-build-actions hook.  Registering another mutator after that point would have no
+// effect on the build that already scheduled its hook, so the *Mutators registrars below treat it
+// as a programming error.
+var mutatorsFinalized = false
+
 func PreArchMutators(f RegisterMutatorFunc) {
+	if mutatorsFinalized {
+		panic("PreArchMutators registered after FinalizeMutators")
+	}
 	preArch = append(preArch, f)
 }
 
 func PreDepsMutators(f RegisterMutatorFunc) {
+	if mutatorsFinalized {
+		panic("PreDepsMutators registered after FinalizeMutators")
+	}
 	preDeps = append(preDeps, f)
 }
 
 func PostDepsMutators(f RegisterMutatorFunc) {
+	if mutatorsFinalized {
+		panic("PostDepsMutators registered after FinalizeMutators")
+	}
 	postDeps = append(postDeps, f)
 }
 
 func FinalDepsMutators(f RegisterMutatorFunc) {
+	if mutatorsFinalized {
+		panic("FinalDepsMutators registered after FinalizeMutators")
+	}
 	finalDeps = append(finalDeps, f)
 }
 
+// beforePrepareBuildActionsHooks are registered with RegisterBeforePrepareBuildActionsHook and
+// run, in registration order, after every mutator phase has completed but before any module's
+// GenerateAndroidBuildActions.  See RegisterBeforePrepareBuildActionsHook for details.
+var beforePrepareBuildActionsHooks []func(HookContext) error
+
+// RegisterBeforePrepareBuildActionsHook registers f to run exactly once after all mutator phases
+// (PreArchMutators through FinalDepsMutators) complete, but before any module's
+// GenerateAndroidBuildActions runs.  f receives a HookContext that can iterate modules and read
+// providers set during the mutator phases, but cannot create modules or variations - by the time
+// it runs the module graph is frozen.  If f returns an error the build is aborted cleanly with
+// whatever source-location diagnostics were reported through the context.
+//
+// This is the place for build-wide invariants that don't belong to any one module's mutator, for
+// example checking that every apex has a matching prebuilt fallback, emitting a manifest of
+// enabled modules for a downstream tool, or rejecting two modules that install to the same path.
+// Those checks used to be shoehorned into singletons that walked the whole module list during
+// GenerateBuildActions; a hook makes the "runs once, after mutators, before build actions" timing
+// explicit instead of incidental.
+func RegisterBeforePrepareBuildActionsHook(f func(HookContext) error) {
+	if mutatorsFinalized {
+		panic("RegisterBeforePrepareBuildActionsHook registered after FinalizeMutators")
+	}
+	beforePrepareBuildActionsHooks = append(beforePrepareBuildActionsHooks, f)
+}
+
+// HookContext is passed to the functions registered with RegisterBeforePrepareBuildActionsHook.
+// It is intentionally narrower than BaseModuleContext: the module graph is already final by the
+// time these hooks run, so there is no MutatorName, Rename, or dependency-adding capability to
+// offer.
+type HookContext interface {
+	// VisitAllModules calls visit on every module in the build graph, in an unspecified order.
+	VisitAllModules(visit func(Module))
+
+	// Provider returns the value for the given provider previously attached to module with
+	// SetProvider, and reports whether it was set.
+	Provider(module Module, key ProviderKey) (interface{}, bool)
+
+	// HasProvider reports whether the given provider has been attached to module.
+	HasProvider(module Module, key ProviderKey) bool
+
+	// Errorf reports an error attributed to the given module, in the same style as
+	// BaseModuleContext.ModuleErrorf, without aborting immediately so that a hook can report
+	// every violation it finds before the build fails.
+	Errorf(module Module, format string, args ...interface{})
+}
+
+type hookContext struct {
+	ctx     *blueprint.Context
+	metrics *mutatorMetricsRecorder
+	errs    []error
+}
+
+func (h *hookContext) VisitAllModules(visit func(Module)) {
+	h.ctx.VisitAllModules(func(m blueprint.Module) {
+		if a, ok := m.(Module); ok {
+			visit(a)
+		}
+	})
+}
+
+func (h *hookContext) Provider(module Module, key ProviderKey) (interface{}, bool) {
+	// Hooks run strictly after every mutator pass, so no real mutator pass index can reject this
+	// read; pass a sentinel that is guaranteed to be greater than any pass index handed out by
+	// registerMutatorsContext.BottomUp/TopDown (those are just positions in mctx.mutators, a
+	// small number even for the whole build).
+	return module.base().providers.provider(key, hookContextPass)
+}
+
+func (h *hookContext) HasProvider(module Module, key ProviderKey) bool {
+	_, ok := h.Provider(module, key)
+	return ok
+}
+
+func (h *hookContext) Errorf(module Module, format string, args ...interface{}) {
+	h.errs = append(h.errs, h.ctx.ModuleErrorf(module, format, args...))
+}
+
+// runBeforePrepareBuildActionsHooks runs every hook registered with
+// RegisterBeforePrepareBuildActionsHook, in registration order, and returns an error if any
+// hook returned one or reported an error through its HookContext.
+func runBeforePrepareBuildActionsHooks(ctx *blueprint.Context, metrics *mutatorMetricsRecorder) error {
+	hctx := &hookContext{ctx: ctx, metrics: metrics}
+	for _, f := range beforePrepareBuildActionsHooks {
+		if err := f(hctx); err != nil {
+			return err
+		}
+	}
+	if len(hctx.errs) > 0 {
+		msgs := make([]string, len(hctx.errs))
+		for i, err := range hctx.errs {
+			msgs[i] = err.Error()
+		}
+		return fmt.Errorf("BeforePrepareBuildActions hooks reported errors:\n%s", strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// FinalizeMutators seals the mutator registration lists (preArch, preDeps, postDeps, finalDeps)
+// and the before-prepare-build-actions hook list, so that a later PreArchMutators (etc.) or
+// RegisterBeforePrepareBuildActionsHook call panics instead of silently registering into a
+// schedule that has already been built.
+//
+// registerMutators itself does NOT call this: it runs once per blueprint.Context, and Soong
+// constructs many Contexts in-process (every test fixture builds its own).  Sealing
+// unconditionally inside registerMutators would permanently wedge every later test in the same
+// process, and would also defeat the point of the guarantee, since there would be no moment of
+// the caller's choosing left to assert against. Call FinalizeMutators explicitly from the single
+// top-level driver that wants this guarantee (e.g. once, from soong_build's main, after its one
+// real registerMutators call) - not from registerMutators itself.
+func FinalizeMutators() {
+	mutatorsFinalized = true
+}
+
 type BaseMutatorContext interface {
 	BaseModuleContext
 
@@ -188,6 +397,87 @@ type BaseMutatorContext interface {
 	// Rename all variants of a module.  The new name is not visible to calls to ModuleName,
 	// AddDependency or OtherModuleName until after this mutator pass is complete.
 	Rename(name string)
+
+	// SetProvider attaches value to the current module under key, so that it can later be read
+	// off the module by its reverse dependencies using Provider.  key must have been obtained
+	// from NewProvider.  Each (module, key) pair may be set at most once; setting it again
+	// panics.
+	SetProvider(key ProviderKey, value interface{})
+
+	// Provider returns the value for the given provider previously attached to module with
+	// SetProvider, and reports whether it was set.  It panics if called at or before the mutator
+	// pass that owns key, since the value cannot exist yet; move the reading mutator later in
+	// the registration order instead.
+	Provider(module Module, key ProviderKey) (interface{}, bool)
+
+	// HasProvider reports whether the given provider has been attached to module.  Like
+	// Provider, it panics if called at or before the mutator pass that owns key.
+	HasProvider(module Module, key ProviderKey) bool
+}
+
+// TransitionMutator splits modules into variants and then resolves each dependency edge to one
+// of those variants, without the module adding the dependency having to guess which variant the
+// dependency wants.
+type TransitionMutator interface {
+	// Split returns the list of variations that should be created for the given module.  If it
+	// returns an empty list the module is not split and behaves as if it had a single variation
+	// named "".
+	Split(ctx BaseModuleContext) []string
+
+	// OutgoingTransition is called on the module that is adding a dependency, and returns the
+	// variation of the dependency requested by this edge.  sourceVariation is the variation of
+	// the module that is adding the dependency, and depTag is the dependency tag that was passed
+	// to AddDependency.  The returned variation is only a request; IncomingTransition on the
+	// dependency gets the final say.
+	OutgoingTransition(ctx BaseModuleContext, sourceVariation string, depTag blueprint.DependencyTag) string
+
+	// IncomingTransition is called on the dependency side of an edge and may rewrite or clamp
+	// the variation requested by OutgoingTransition, for example snapping an arch-specific
+	// request to "common" for a host-only library.  The returned variation must be one of the
+	// variations this module itself returned from Split.
+	IncomingTransition(ctx BaseModuleContext, requestedVariation string) string
+}
+
+// transitionMutatorAdaptor adapts a TransitionMutator, which is expressed in terms of Soong's
+// android.Module and android.BaseModuleContext, to the blueprint.TransitionMutator interface
+// that the underlying blueprint.Context actually schedules.
+type transitionMutatorAdaptor struct {
+	mutator TransitionMutator
+}
+
+func (a *transitionMutatorAdaptor) Split(ctx blueprint.BaseModuleContext) []string {
+	if m, ok := ctx.Module().(Module); ok {
+		return a.mutator.Split(m.base().baseModuleContextFactory(ctx))
+	}
+	return []string{""}
+}
+
+func (a *transitionMutatorAdaptor) OutgoingTransition(ctx blueprint.BaseModuleContext,
+	sourceVariation string, depTag blueprint.DependencyTag) string {
+
+	if m, ok := ctx.Module().(Module); ok {
+		return a.mutator.OutgoingTransition(m.base().baseModuleContextFactory(ctx), sourceVariation, depTag)
+	}
+	return sourceVariation
+}
+
+func (a *transitionMutatorAdaptor) IncomingTransition(ctx blueprint.BaseModuleContext,
+	requestedVariation string) string {
+
+	if m, ok := ctx.Module().(Module); ok {
+		return a.mutator.IncomingTransition(m.base().baseModuleContextFactory(ctx), requestedVariation)
+	}
+	return requestedVariation
+}
+
+func (x *registerMutatorsContext) Transition(name string, m TransitionMutator) MutatorHandle {
+	if x.finalPhase {
+		panic("TransitionMutator not allowed in FinalDepsMutators")
+	}
+
+	mutator := &mutator{name: name, transitionMutator: &transitionMutatorAdaptor{mutator: m}}
+	x.mutators = append(x.mutators, mutator)
+	return mutator
 }
 
 type TopDownMutator func(TopDownMutatorContext)
@@ -203,6 +493,7 @@ type TopDownMutatorContext interface {
 type topDownMutatorContext struct {
 	bp blueprint.TopDownMutatorContext
 	baseModuleContext
+	pass int
 }
 
 type BottomUpMutator func(BottomUpMutatorContext)
@@ -213,6 +504,10 @@ type BottomUpMutatorContext interface {
 	// AddDependency adds a dependency to the given module.
 	// Does not affect the ordering of the current mutator pass, but will be ordered
 	// correctly for all future mutator passes.
+	//
+	// Prefer the package-level AddTypedDependency when the dependency tag has a single concrete
+	// type, so the consumer's VisitDirectDeps can use VisitDirectDepsWithTag instead of its own
+	// type switch.
 	AddDependency(module blueprint.Module, tag blueprint.DependencyTag, name ...string)
 
 	// AddReverseDependency adds a dependency from the destination to the given module.
@@ -306,23 +601,29 @@ type bottomUpMutatorContext struct {
 	bp blueprint.BottomUpMutatorContext
 	baseModuleContext
 	finalPhase bool
+	pass       int
+	metrics    *mutatorMetricsRecorder
 }
 
 func bottomUpMutatorContextFactory(ctx blueprint.BottomUpMutatorContext, a Module,
-	finalPhase bool) BottomUpMutatorContext {
+	finalPhase bool, pass int, metrics *mutatorMetricsRecorder) BottomUpMutatorContext {
 
 	return &bottomUpMutatorContext{
 		bp:                ctx,
 		baseModuleContext: a.base().baseModuleContextFactory(ctx),
 		finalPhase:        finalPhase,
+		pass:              pass,
+		metrics:           metrics,
 	}
 }
 
 func (x *registerMutatorsContext) BottomUp(name string, m BottomUpMutator) MutatorHandle {
 	finalPhase := x.finalPhase
+	pass := len(x.mutators)
+	metrics := x.metrics
 	f := func(ctx blueprint.BottomUpMutatorContext) {
 		if a, ok := ctx.Module().(Module); ok {
-			m(bottomUpMutatorContextFactory(ctx, a, finalPhase))
+			m(bottomUpMutatorContextFactory(ctx, a, finalPhase, pass, metrics))
 		}
 	}
 	mutator := &mutator{name: name, bottomUpMutator: f}
@@ -337,11 +638,13 @@ func (x *registerMutatorsContext) BottomUpBlueprint(name string, m blueprint.Bot
 }
 
 func (x *registerMutatorsContext) TopDown(name string, m TopDownMutator) MutatorHandle {
+	pass := len(x.mutators)
 	f := func(ctx blueprint.TopDownMutatorContext) {
 		if a, ok := ctx.Module().(Module); ok {
 			actx := &topDownMutatorContext{
 				bp:                ctx,
 				baseModuleContext: a.base().baseModuleContextFactory(ctx),
+				pass:              pass,
 			}
 			m(actx)
 		}
@@ -351,6 +654,13 @@ func (x *registerMutatorsContext) TopDown(name string, m TopDownMutator) Mutator
 	return mutator
 }
 
+func (x *registerMutatorsContext) DeclareProvider(key ProviderKey) {
+	if len(x.mutators) == 0 {
+		panic("DeclareProvider must be called after registering the mutator that produces the provider")
+	}
+	declareProviderProducerPass(key, len(x.mutators)-1)
+}
+
 type MutatorHandle interface {
 	Parallel() MutatorHandle
 }
@@ -422,6 +732,19 @@ func (t *topDownMutatorContext) Rename(name string) {
 	t.Module().base().commonProperties.DebugName = name
 }
 
+func (t *topDownMutatorContext) SetProvider(key ProviderKey, value interface{}) {
+	t.Module().base().providers.setProvider(key, t.pass, value)
+}
+
+func (t *topDownMutatorContext) Provider(module Module, key ProviderKey) (interface{}, bool) {
+	return module.base().providers.provider(key, t.pass)
+}
+
+func (t *topDownMutatorContext) HasProvider(module Module, key ProviderKey) bool {
+	_, ok := module.base().providers.provider(key, t.pass)
+	return ok
+}
+
 func (t *topDownMutatorContext) CreateModule(factory ModuleFactory, props ...interface{}) Module {
 	inherited := []interface{}{&t.Module().base().commonProperties}
 	module := t.bp.CreateModule(ModuleFactoryAdaptor(factory), append(inherited, props...)...).(Module)
@@ -452,12 +775,27 @@ func (b *bottomUpMutatorContext) Rename(name string) {
 	b.Module().base().commonProperties.DebugName = name
 }
 
+func (b *bottomUpMutatorContext) SetProvider(key ProviderKey, value interface{}) {
+	b.Module().base().providers.setProvider(key, b.pass, value)
+}
+
+func (b *bottomUpMutatorContext) Provider(module Module, key ProviderKey) (interface{}, bool) {
+	return module.base().providers.provider(key, b.pass)
+}
+
+func (b *bottomUpMutatorContext) HasProvider(module Module, key ProviderKey) bool {
+	_, ok := module.base().providers.provider(key, b.pass)
+	return ok
+}
+
 func (b *bottomUpMutatorContext) AddDependency(module blueprint.Module, tag blueprint.DependencyTag, name ...string) {
 	b.bp.AddDependency(module, tag, name...)
+	b.metrics.record(b.MutatorName(), 0, 0, len(name))
 }
 
 func (b *bottomUpMutatorContext) AddReverseDependency(module blueprint.Module, tag blueprint.DependencyTag, name string) {
 	b.bp.AddReverseDependency(module, tag, name)
+	b.metrics.record(b.MutatorName(), 0, 0, 1)
 }
 
 func (b *bottomUpMutatorContext) CreateVariations(variations ...string) []Module {
@@ -474,6 +812,7 @@ func (b *bottomUpMutatorContext) CreateVariations(variations ...string) []Module
 		base.commonProperties.DebugMutators = append(base.commonProperties.DebugMutators, b.MutatorName())
 		base.commonProperties.DebugVariations = append(base.commonProperties.DebugVariations, variations[i])
 	}
+	b.metrics.record(b.MutatorName(), 0, len(variations), 0)
 
 	return aModules
 }
@@ -492,6 +831,7 @@ func (b *bottomUpMutatorContext) CreateLocalVariations(variations ...string) []M
 		base.commonProperties.DebugMutators = append(base.commonProperties.DebugMutators, b.MutatorName())
 		base.commonProperties.DebugVariations = append(base.commonProperties.DebugVariations, variations[i])
 	}
+	b.metrics.record(b.MutatorName(), 0, len(variations), 0)
 
 	return aModules
 }
@@ -508,12 +848,14 @@ func (b *bottomUpMutatorContext) AddVariationDependencies(variations []blueprint
 	names ...string) {
 
 	b.bp.AddVariationDependencies(variations, tag, names...)
+	b.metrics.record(b.MutatorName(), 0, 0, len(names))
 }
 
 func (b *bottomUpMutatorContext) AddFarVariationDependencies(variations []blueprint.Variation,
 	tag blueprint.DependencyTag, names ...string) {
 
 	b.bp.AddFarVariationDependencies(variations, tag, names...)
+	b.metrics.record(b.MutatorName(), 0, 0, len(names))
 }
 
 func (b *bottomUpMutatorContext) AddInterVariantDependency(tag blueprint.DependencyTag, from, to blueprint.Module) {
@@ -0,0 +1,59 @@
+// Copyright 2022 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import "testing"
+
+// A hook reading a provider must succeed even when the producing mutator ran at a late pass
+// index, since hooks read at hookContextPass, after the whole mutator phase rather than at any
+// one pass in it.
+func TestHookContextProviderReadsAfterLateMutatorPass(t *testing.T) {
+	key := NewProvider(providerTestValue{})
+
+	var p providers
+	p.setProvider(key, 50, providerTestValue{Count: 7})
+
+	value, ok := p.provider(key, hookContextPass)
+	if !ok {
+		t.Fatal("expected hook read to find the provider")
+	}
+	if value.(providerTestValue).Count != 7 {
+		t.Fatalf("got %+v, want Count 7", value)
+	}
+}
+
+func TestFinalizeMutatorsIsNotCalledAutomatically(t *testing.T) {
+	if mutatorsFinalized {
+		t.Skip("mutatorsFinalized already set by an earlier test in this process; nothing to assert")
+	}
+
+	PreArchMutators(func(RegisterMutatorsContext) {})
+}
+
+// TestFinalizeMutatorsSealsRegistration exercises the marker FinalizeMutators sets so that tests
+// can assert no late registration slipped in after the hook was scheduled. mutatorsFinalized has
+// no unseal, so this must stay the last test in the package allowed to call PreArchMutators - it
+// runs after TestFinalizeMutatorsIsNotCalledAutomatically above, which depends on it not having
+// run yet.
+func TestFinalizeMutatorsSealsRegistration(t *testing.T) {
+	FinalizeMutators()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected PreArchMutators to panic after FinalizeMutators")
+		}
+	}()
+	PreArchMutators(func(RegisterMutatorsContext) {})
+}
@@ -0,0 +1,215 @@
+// Copyright 2022 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"time"
+)
+
+// This file gives per-mutator visibility into the mutator phase: how long each registered
+// mutator spent across the whole tree, how many modules it touched, and how many variations and
+// dependencies it created.  Soong's mutator wrappers are the only place that can bracket a
+// mutator invocation, since Blueprint itself just calls the function it was given, so the
+// instrumentation lives here rather than in Blueprint - but the timing itself reuses the same
+// BeginEvent/EndEvent EventHandler pattern Blueprint's own bootstrap uses, bracketing each
+// mutator invocation as event "mutator.<name>" on config.EventHandler(). That's the same
+// EventHandler that already ends up in out/soong_metrics, so these events are picked up by the
+// existing soong_metrics pipeline for free instead of this file writing its own separate file.
+
+// mutatorEventName is the EventHandler event name a mutator's invocations are bracketed with.
+func mutatorEventName(mutatorName string) string {
+	return "mutator." + mutatorName
+}
+
+// MutatorStats is a read-only snapshot of one mutator's cumulative cost across a build, as
+// returned by Config.MutatorStats.
+type MutatorStats struct {
+	Name           string
+	Duration       time.Duration
+	ModuleCount    int
+	VariationCount int
+	DepCount       int
+}
+
+// mutatorMetrics accumulates the Soong-specific counters for a single mutator as it runs across
+// the tree - the cumulative duration itself lives on config.EventHandler(), not here.
+type mutatorMetrics struct {
+	name           string
+	moduleCount    int
+	variationCount int
+	depCount       int
+}
+
+// mutatorMetricsRecorder collects mutatorMetrics for every mutator registered in a build.  It is
+// carried on Config so that every wrapper built by BottomUp, TopDown and BottomUpBlueprint
+// reports to the same place regardless of which phase registered it.
+type mutatorMetricsRecorder struct {
+	mu    sync.Mutex
+	stats map[string]*mutatorMetrics
+	order []string
+}
+
+func newMutatorMetricsRecorder() *mutatorMetricsRecorder {
+	return &mutatorMetricsRecorder{stats: make(map[string]*mutatorMetrics)}
+}
+
+func (r *mutatorMetricsRecorder) record(name string, moduleCount, variationCount, depCount int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.stats[name]
+	if !ok {
+		s = &mutatorMetrics{name: name}
+		r.stats[name] = s
+		r.order = append(r.order, name)
+	}
+	s.moduleCount += moduleCount
+	s.variationCount += variationCount
+	s.depCount += depCount
+}
+
+// snapshot returns the recorded stats for every mutator, in registration order, with Duration
+// filled in from eventHandler's cumulative time for that mutator's event.
+func (r *mutatorMetricsRecorder) snapshot(eventHandler mutatorEventDurations) []MutatorStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]MutatorStats, 0, len(r.order))
+	for _, name := range r.order {
+		s := r.stats[name]
+		result = append(result, MutatorStats{
+			Name:           s.name,
+			Duration:       eventHandler.CumulativeTime(mutatorEventName(name)),
+			ModuleCount:    s.moduleCount,
+			VariationCount: s.variationCount,
+			DepCount:       s.depCount,
+		})
+	}
+	return result
+}
+
+// mutatorEventDurations is the slice of blueprint/metrics.EventHandler that MutatorStats needs:
+// the cumulative time recorded for a named event across every Begin/End bracket around it.
+type mutatorEventDurations interface {
+	CumulativeTime(name string) time.Duration
+}
+
+// slowMutatorWarningCount is how many entries are printed by the "slowest mutators" warning.
+const slowMutatorWarningCount = 10
+
+// slowMutatorThreshold is the cumulative duration a mutator must exceed before it is eligible
+// for the slowest-mutators warning.  Configurable so small trees don't get spammed.
+var slowMutatorThreshold = 100 * time.Millisecond
+
+// topSlowMutators returns up to n of the given stats sorted by descending cumulative duration,
+// for use in the "top-N slowest mutators" warning printed after the mutator phase completes.
+func topSlowMutators(stats []MutatorStats, n int) []MutatorStats {
+	sorted := append([]MutatorStats(nil), stats...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Duration > sorted[j].Duration })
+
+	var slow []MutatorStats
+	for _, s := range sorted {
+		if s.Duration > slowMutatorThreshold {
+			slow = append(slow, s)
+		}
+	}
+	if len(slow) > n {
+		slow = slow[:n]
+	}
+	return slow
+}
+
+// logSlowMutators prints a warning naming the slowest mutators in this build, if any exceeded
+// slowMutatorThreshold.  It's called from the BeforePrepareBuildActions hook registerMutators
+// schedules, since that's the first point after registration where every mutator has actually
+// run and config.EventHandler()'s cumulative times are final.
+func logSlowMutators(c Config) {
+	if slow := topSlowMutators(c.MutatorStats(), slowMutatorWarningCount); len(slow) > 0 {
+		fmt.Println("soong: slowest mutators:")
+		for _, s := range slow {
+			fmt.Printf("  %-30s %-10s %d modules\n", s.Name, s.Duration, s.ModuleCount)
+		}
+	}
+}
+
+// traceEvent is a single Chrome trace-event JSON object, written by -mutator_trace so the
+// mutator schedule can be loaded in chrome://tracing.
+type traceEvent struct {
+	Name      string `json:"name"`
+	Phase     string `json:"ph"`
+	Timestamp int64  `json:"ts"`
+	Duration  int64  `json:"dur,omitempty"`
+	Pid       int    `json:"pid"`
+	Tid       int    `json:"tid"`
+}
+
+// setMutatorMetricsRecorder stashes the recorder populated during the mutator phase on Config so
+// that MutatorStats and -mutator_trace can reach it afterwards.
+func (c Config) setMutatorMetricsRecorder(r *mutatorMetricsRecorder) {
+	c.config.mutatorMetrics = r
+}
+
+// SetMutatorTracePath records path as the destination WriteMutatorTrace writes to once the mutator
+// phase finishes, for the -mutator_trace=<file> flag. It's called from cmd/soong_build's flag
+// parsing; an empty path (the default, when the flag wasn't passed) means no trace is written.
+func (c Config) SetMutatorTracePath(path string) {
+	c.config.mutatorTracePath = path
+}
+
+// MutatorStats returns the aggregated per-mutator cost for the mutator phase that just ran:
+// cumulative time (from config.EventHandler()'s "mutator.<name>" events), module count,
+// variations created and dependencies added, one entry per bottom-up or top-down mutator that
+// ran on at least one module, in registration order.  Transition mutators are not instrumented
+// and have no entry.
+func (c Config) MutatorStats() []MutatorStats {
+	if c.config.mutatorMetrics == nil {
+		return nil
+	}
+	return c.config.mutatorMetrics.snapshot(c.EventHandler())
+}
+
+// WriteMutatorTrace writes the recorded mutator stats to path as Chrome trace-event JSON, for
+// the -mutator_trace=<file> flag.  Each mutator is rendered as a single complete event ("X")
+// spanning its cumulative duration; it does not attempt to reconstruct per-module timing since
+// mutators run across many modules in parallel.  Unlike the per-mutator durations, which ride
+// along on the existing out/soong_metrics EventHandler data, this is its own artifact because
+// -mutator_trace asks for a standalone file to load in chrome://tracing.
+func WriteMutatorTrace(c Config, path string) error {
+	stats := c.MutatorStats()
+	events := make([]traceEvent, 0, len(stats))
+	var cursor int64
+	for _, s := range stats {
+		events = append(events, traceEvent{
+			Name:      s.Name,
+			Phase:     "X",
+			Timestamp: cursor,
+			Duration:  s.Duration.Microseconds(),
+			Pid:       1,
+			Tid:       1,
+		})
+		cursor += s.Duration.Microseconds()
+	}
+
+	b, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
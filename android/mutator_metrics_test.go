@@ -0,0 +1,68 @@
+// Copyright 2022 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeEventDurations map[string]time.Duration
+
+func (f fakeEventDurations) CumulativeTime(name string) time.Duration {
+	return f[name]
+}
+
+func TestMutatorMetricsRecorderSnapshot(t *testing.T) {
+	r := newMutatorMetricsRecorder()
+	r.record("arch", 3, 2, 1)
+	r.record("arch", 1, 0, 4)
+	r.record("os", 5, 0, 0)
+
+	durations := fakeEventDurations{
+		mutatorEventName("arch"): 10 * time.Millisecond,
+		mutatorEventName("os"):   2 * time.Millisecond,
+	}
+
+	stats := r.snapshot(durations)
+	if len(stats) != 2 {
+		t.Fatalf("got %d stats, want 2", len(stats))
+	}
+
+	arch := stats[0]
+	if arch.Name != "arch" || arch.ModuleCount != 4 || arch.VariationCount != 2 || arch.DepCount != 5 {
+		t.Fatalf("got %+v, want merged arch counters", arch)
+	}
+	if arch.Duration != 10*time.Millisecond {
+		t.Fatalf("got duration %v, want 10ms", arch.Duration)
+	}
+}
+
+func TestTopSlowMutatorsFiltersAndLimits(t *testing.T) {
+	orig := slowMutatorThreshold
+	slowMutatorThreshold = 5 * time.Millisecond
+	defer func() { slowMutatorThreshold = orig }()
+
+	stats := []MutatorStats{
+		{Name: "fast", Duration: 1 * time.Millisecond},
+		{Name: "slow1", Duration: 50 * time.Millisecond},
+		{Name: "slow2", Duration: 20 * time.Millisecond},
+	}
+
+	got := topSlowMutators(stats, 1)
+	if len(got) != 1 || got[0].Name != "slow1" {
+		t.Fatalf("got %+v, want only slow1", got)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2015 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import "testing"
+
+func TestTransitionPanicsInFinalPhase(t *testing.T) {
+	mctx := &registerMutatorsContext{finalPhase: true}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Transition to panic when registered in FinalDepsMutators, like CreateVariations")
+		}
+	}()
+	mctx.Transition("test", nil)
+}
+
+func TestTransitionRegistersAMutator(t *testing.T) {
+	mctx := &registerMutatorsContext{}
+	mctx.Transition("test", nil)
+
+	if len(mctx.mutators) != 1 {
+		t.Fatalf("got %d mutators, want 1", len(mctx.mutators))
+	}
+	if mctx.mutators[0].name != "test" {
+		t.Fatalf("got name %q, want %q", mctx.mutators[0].name, "test")
+	}
+	if mctx.mutators[0].transitionMutator == nil {
+		t.Fatal("expected transitionMutator to be set")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2021 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"reflect"
+	"sync"
+)
+
+// This file implements a typed, write-once mechanism for a module to publish structured
+// information that can be read by its reverse dependencies, as an alternative to exposing
+// exported fields or having consumers re-derive facts from the dependency graph themselves via
+// VisitDirectDeps.
+
+// ProviderKey is an opaque identifier for a provider, created by NewProvider.
+type ProviderKey struct {
+	id  int
+	typ reflect.Type
+}
+
+// providerKeyInfo is the package-wide (not per-module) record for a single ProviderKey.  Its
+// producerPass field is the "sealed after" pass: the mutator pass number of the mutator that
+// produces this provider.  It's normally filled in by RegisterMutatorsContext.DeclareProvider at
+// mutator-registration time, before the producing mutator has run even once, so Provider/HasProvider
+// can reject a too-early read even for a build where the producing mutator legitimately never calls
+// SetProvider on any module (e.g. an optional provider nobody needed) - unlike inferring "known"
+// from the producing mutator's first actual SetProvider call, which can't distinguish that case
+// from "hasn't run yet" and would otherwise panic on every read, forever.  A producer that doesn't
+// call DeclareProvider still gets this filled in lazily by its first SetProvider call, so ad hoc
+// providers (not produced by a registered mutator) keep working.
+type providerKeyInfo struct {
+	typ reflect.Type
+
+	mu           sync.Mutex
+	known        bool
+	producerPass int
+}
+
+var providerRegistry []*providerKeyInfo
+
+// NewProvider returns a ProviderKey that can be used with SetProvider and Provider/HasProvider to
+// attach a value of the same type as exampleValue to a module.  NewProvider must be called from a
+// package-level variable initializer so that the returned ProviderKey is stable across the whole
+// build.
+//
+//	var complianceMetadataProvider = android.NewProvider(ComplianceMetadataInfo{})
+func NewProvider(exampleValue interface{}) ProviderKey {
+	info := &providerKeyInfo{typ: reflect.TypeOf(exampleValue)}
+	id := len(providerRegistry)
+	providerRegistry = append(providerRegistry, info)
+	return ProviderKey{id: id, typ: info.typ}
+}
+
+// providerValue holds a single provider's value as set on one module.
+type providerValue struct {
+	value    interface{}
+	hasValue bool
+}
+
+// providers stores all the provider values set on a single module, indexed by ProviderKey.id.
+type providers struct {
+	values []providerValue
+}
+
+// declareProviderProducerPass records pass as key's "sealed after" pass, unless one was already
+// recorded - either by an earlier call to this function, or by an earlier SetProvider call for
+// some module (setProvider only fills this in lazily if nothing claimed it first). Called by
+// RegisterMutatorsContext.DeclareProvider at mutator-registration time, so the pass is known
+// before the producing mutator has run even once.
+func declareProviderProducerPass(key ProviderKey, pass int) {
+	info := providerRegistry[key.id]
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	if !info.known {
+		info.producerPass = pass
+		info.known = true
+	}
+}
+
+func (p *providers) setProvider(key ProviderKey, pass int, value interface{}) {
+	info := providerRegistry[key.id]
+	info.mu.Lock()
+	if !info.known {
+		info.producerPass = pass
+		info.known = true
+	}
+	info.mu.Unlock()
+
+	if p.values == nil {
+		p.values = make([]providerValue, len(providerRegistry))
+	}
+	if len(p.values) <= key.id {
+		grown := make([]providerValue, len(providerRegistry))
+		copy(grown, p.values)
+		p.values = grown
+	}
+	if p.values[key.id].hasValue {
+		panic("provider value for " + key.typ.String() + " already set, providers are write-once")
+	}
+
+	// Shallow-copy the value so that later mutation of the caller's struct can't reach back and
+	// change what consumers see.  Pointers, slices and maps inside the value are not copied, so
+	// whatever they refer to is still shared with the caller.
+	v := reflect.New(key.typ).Elem()
+	v.Set(reflect.ValueOf(value))
+
+	p.values[key.id] = providerValue{
+		value:    v.Interface(),
+		hasValue: true,
+	}
+}
+
+// provider returns the value set on p for key, and reports whether it was set.  It panics if
+// pass is at or before the pass of the mutator that owns key, regardless of whether this
+// particular module already has a value - an earlier pass reading a provider that simply hasn't
+// been produced for this module yet is exactly the "read before the producing mutator ran" bug
+// this is meant to catch, not a legitimate "not set" result.
+func (p *providers) provider(key ProviderKey, pass int) (interface{}, bool) {
+	info := providerRegistry[key.id]
+	info.mu.Lock()
+	known, producerPass := info.known, info.producerPass
+	info.mu.Unlock()
+
+	if !known || pass <= producerPass {
+		panic("provider for " + key.typ.String() + " read at or before the mutator pass that set it; " +
+			"move the reading mutator to run after the one registered for this provider")
+	}
+
+	if p.values == nil || len(p.values) <= key.id {
+		return nil, false
+	}
+	pv := p.values[key.id]
+	return pv.value, pv.hasValue
+}
@@ -0,0 +1,120 @@
+// Copyright 2021 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import "testing"
+
+type providerTestValue struct {
+	Count int
+}
+
+func TestProviderWriteOnce(t *testing.T) {
+	key := NewProvider(providerTestValue{})
+
+	var p providers
+	p.setProvider(key, 1, providerTestValue{Count: 1})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected setProvider to panic on a second call for the same key")
+		}
+	}()
+	p.setProvider(key, 1, providerTestValue{Count: 2})
+}
+
+func TestProviderReadAfterProducerPass(t *testing.T) {
+	key := NewProvider(providerTestValue{})
+
+	var p providers
+	p.setProvider(key, 5, providerTestValue{Count: 42})
+
+	value, ok := p.provider(key, 6)
+	if !ok {
+		t.Fatal("expected provider to be set")
+	}
+	if value.(providerTestValue).Count != 42 {
+		t.Fatalf("got %+v, want Count 42", value)
+	}
+}
+
+func TestProviderReadAtOrBeforeProducerPassPanics(t *testing.T) {
+	key := NewProvider(providerTestValue{})
+
+	var p providers
+	p.setProvider(key, 5, providerTestValue{Count: 42})
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected provider read at the producer's own pass to panic")
+		}
+	}()
+	p.provider(key, 5)
+}
+
+func TestProviderReadBeforeAnyoneProducedItPanics(t *testing.T) {
+	key := NewProvider(providerTestValue{})
+
+	var p providers
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected provider read before the producing mutator has ever run to panic, not silently report unset")
+		}
+	}()
+	p.provider(key, 0)
+}
+
+func TestProviderDeclaredButNeverSetReadsAsUnsetAfterProducerPass(t *testing.T) {
+	key := NewProvider(providerTestValue{})
+	declareProviderProducerPass(key, 2)
+
+	// The producing mutator declared it owns this key at registration time, but in this build it
+	// never actually called SetProvider on any module - e.g. an optional provider nobody needed.
+	// A read after its pass must report "not set", not panic, even though setProvider was never
+	// called for this key at all.
+	var p providers
+	_, ok := p.provider(key, 3)
+	if ok {
+		t.Fatal("expected ok=false for a provider whose producer ran but set nothing")
+	}
+}
+
+func TestProviderDeclaredReadAtOrBeforeProducerPassStillPanics(t *testing.T) {
+	key := NewProvider(providerTestValue{})
+	declareProviderProducerPass(key, 2)
+
+	var p providers
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected provider read at the declared producer's own pass to panic")
+		}
+	}()
+	p.provider(key, 2)
+}
+
+func TestProviderUnsetOnOtherModuleAfterProducerPass(t *testing.T) {
+	key := NewProvider(providerTestValue{})
+
+	var producer providers
+	producer.setProvider(key, 3, providerTestValue{Count: 1})
+
+	// A different module that the producing mutator simply didn't set the provider on should
+	// read back "not set" rather than panicking, as long as the read happens after the pass that
+	// produces the provider.
+	var other providers
+	_, ok := other.provider(key, 4)
+	if ok {
+		t.Fatal("expected ok=false for a module the provider was never set on")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2022 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/google/blueprint"
+)
+
+// AddDependency and VisitDirectDeps take and return a DependencyTag as an interface{}-ish value,
+// which means every consumer re-implements its own type switch in VisitDirectDeps to fish out
+// the one tag type it cares about.  The helpers in this file add a dependency or walk the graph
+// for a single concrete tag type T, so that type switch only has to be written once.  Go doesn't
+// allow a generic method, so these are free functions that take the context as their first
+// argument rather than being declared directly on BottomUpMutatorContext / BaseModuleContext.
+
+// dependencyTagTypeRegistry records which mutator first added a dependency carrying each concrete
+// tag type, so a diagnostic can name the offending mutator if that tag type is ever observed at a
+// phase that shouldn't see it yet.
+var (
+	dependencyTagTypeRegistryMu sync.Mutex
+	dependencyTagTypeRegistry   = map[reflect.Type]string{}
+)
+
+// resetDependencyTagTypeRegistry discards every recorded tag type owner.  registerMutators calls
+// this alongside resetTransitiveDepsCache once at the start of each build (each blueprint.Context
+// it sets up), so a tag type registered by an earlier build - or by an earlier test's Context,
+// since Soong constructs a fresh one per unit test in the same process - can't be mistaken for a
+// conflicting owner in a later one.
+func resetDependencyTagTypeRegistry() {
+	dependencyTagTypeRegistryMu.Lock()
+	defer dependencyTagTypeRegistryMu.Unlock()
+	dependencyTagTypeRegistry = map[reflect.Type]string{}
+}
+
+// registerDependencyTagType records mutatorName as the owner of tag's concrete type the first
+// time it's seen, and enforces that every later AddTypedDependency call for that same concrete
+// tag type comes from the same mutator - if it doesn't, that's exactly the "tag observed at a
+// phase that shouldn't see it" case the registry exists to catch, so it panics naming both
+// mutators instead of silently accepting a second owner.
+func registerDependencyTagType(tag blueprint.DependencyTag, mutatorName string) {
+	t := reflect.TypeOf(tag)
+
+	dependencyTagTypeRegistryMu.Lock()
+	defer dependencyTagTypeRegistryMu.Unlock()
+	if owner, ok := dependencyTagTypeRegistry[t]; ok {
+		if owner != mutatorName {
+			panic(fmt.Sprintf("dependency tag type %s was first added by mutator %q but is now being "+
+				"added by mutator %q; each concrete DependencyTag type must be owned by a single mutator "+
+				"so VisitDirectDepsWithTag/WalkDepsWithTag callers can rely on which phase produced it",
+				t, owner, mutatorName))
+		}
+		return
+	}
+	dependencyTagTypeRegistry[t] = mutatorName
+}
+
+// DependencyTagMutator returns the name of the mutator that first added a dependency carrying a
+// tag of the same concrete type as tag, or "" if no such dependency has been added yet.
+func DependencyTagMutator(tag blueprint.DependencyTag) string {
+	dependencyTagTypeRegistryMu.Lock()
+	defer dependencyTagTypeRegistryMu.Unlock()
+	return dependencyTagTypeRegistry[reflect.TypeOf(tag)]
+}
+
+// AddTypedDependency adds a dependency on each of names, carrying tag, and records which mutator
+// introduced this concrete tag type so DependencyTagMutator can report it later.  It is the typed
+// equivalent of BottomUpMutatorContext.AddDependency.
+func AddTypedDependency[T blueprint.DependencyTag](ctx BottomUpMutatorContext, tag T, names ...string) {
+	registerDependencyTagType(tag, ctx.MutatorName())
+	for _, name := range names {
+		ctx.AddDependency(ctx.Module(), tag, name)
+	}
+}
+
+// VisitDirectDepsWithTag calls visit for every direct dependency of ctx's module whose dependency
+// tag has concrete type T, passing the dependency along with its tag already asserted to T.
+func VisitDirectDepsWithTag[T blueprint.DependencyTag](ctx BaseModuleContext, visit func(Module, T)) {
+	ctx.VisitDirectDeps(func(dep Module) {
+		if tag, ok := ctx.OtherModuleDependencyTag(dep).(T); ok {
+			visit(dep, tag)
+		}
+	})
+}
+
+// WalkDepsWithTag walks the transitive dependency graph reachable from ctx's module, following
+// only edges whose tag has concrete type T, and calls visit on each module reached that way.
+// visit reports whether to keep walking past the given module, the same as blueprint's WalkDeps.
+func WalkDepsWithTag[T blueprint.DependencyTag](ctx BaseModuleContext, visit func(Module, T) bool) {
+	ctx.WalkDeps(func(child, parent Module) bool {
+		tag, ok := ctx.OtherModuleDependencyTag(child).(T)
+		if !ok {
+			return false
+		}
+		return visit(child, tag)
+	})
+}
+
+// transitiveDepsCacheKey identifies one (module, tag type) pair for the TransitiveDeps cache.
+type transitiveDepsCacheKey struct {
+	module Module
+	tag    reflect.Type
+}
+
+var (
+	transitiveDepsCacheMu sync.Mutex
+	transitiveDepsCache   = map[transitiveDepsCacheKey][]Module{}
+)
+
+// resetTransitiveDepsCache discards every cached TransitiveDeps result.  registerMutators calls
+// this once at the start of each build (each blueprint.Context it sets up), so a result cached by
+// an earlier build - or by an earlier test's Context, since Soong constructs a fresh one per unit
+// test in the same process - can never leak into a later one. It does NOT protect against calling
+// TransitiveDeps too early within a single build: like Provider, TransitiveDeps(tag) must only be
+// called after every mutator that adds dependencies carrying tag has already run, or the cached
+// result will be incomplete for the rest of that build.
+func resetTransitiveDepsCache() {
+	transitiveDepsCacheMu.Lock()
+	defer transitiveDepsCacheMu.Unlock()
+	transitiveDepsCache = map[transitiveDepsCacheKey][]Module{}
+}
+
+// TransitiveDeps returns every module transitively reachable from ctx's module by following only
+// edges carrying a tag of concrete type T, deduplicated and in a deterministic first-visited
+// order.  The result is computed once per (module, T) per build and cached, so cc and java don't
+// each reimplement the same DFS.
+func TransitiveDeps[T blueprint.DependencyTag](ctx BaseModuleContext) []Module {
+	key := transitiveDepsCacheKey{
+		module: ctx.Module(),
+		tag:    reflect.TypeOf((*T)(nil)).Elem(),
+	}
+
+	transitiveDepsCacheMu.Lock()
+	if cached, ok := transitiveDepsCache[key]; ok {
+		transitiveDepsCacheMu.Unlock()
+		return cached
+	}
+	transitiveDepsCacheMu.Unlock()
+
+	seen := map[Module]bool{}
+	var ordered []Module
+	WalkDepsWithTag[T](ctx, func(dep Module, _ T) bool {
+		if seen[dep] {
+			return false
+		}
+		seen[dep] = true
+		ordered = append(ordered, dep)
+		return true
+	})
+
+	transitiveDepsCacheMu.Lock()
+	transitiveDepsCache[key] = ordered
+	transitiveDepsCacheMu.Unlock()
+
+	return ordered
+}
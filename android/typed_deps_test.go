@@ -0,0 +1,84 @@
+// Copyright 2022 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+import (
+	"reflect"
+	"testing"
+)
+
+type fakeDependencyTag struct {
+	name string
+}
+
+type otherFakeDependencyTag struct {
+	name string
+}
+
+func TestDependencyTagMutatorRecordsFirstOwner(t *testing.T) {
+	tag := fakeDependencyTag{}
+	registerDependencyTagType(tag, "first_mutator")
+
+	if got := DependencyTagMutator(tag); got != "first_mutator" {
+		t.Fatalf("DependencyTagMutator() = %q, want %q", got, "first_mutator")
+	}
+}
+
+func TestDependencyTagTypeOwnershipIsEnforced(t *testing.T) {
+	tag := otherFakeDependencyTag{}
+	registerDependencyTagType(tag, "owner_mutator")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected registering the same tag type from a different mutator to panic")
+		}
+	}()
+	registerDependencyTagType(tag, "other_mutator")
+}
+
+func TestResetDependencyTagTypeRegistryClearsEntries(t *testing.T) {
+	tag := fakeDependencyTag{}
+	registerDependencyTagType(tag, "first_mutator")
+
+	resetDependencyTagTypeRegistry()
+
+	if got := DependencyTagMutator(tag); got != "" {
+		t.Fatalf("DependencyTagMutator() = %q after reset, want \"\"", got)
+	}
+
+	// A different mutator re-registering the same tag type after a reset must not be treated as
+	// a conflicting owner.
+	registerDependencyTagType(tag, "second_mutator")
+	if got := DependencyTagMutator(tag); got != "second_mutator" {
+		t.Fatalf("DependencyTagMutator() = %q, want %q", got, "second_mutator")
+	}
+}
+
+func TestResetTransitiveDepsCacheClearsEntries(t *testing.T) {
+	key := transitiveDepsCacheKey{tag: reflect.TypeOf(fakeDependencyTag{})}
+
+	transitiveDepsCacheMu.Lock()
+	transitiveDepsCache[key] = []Module{}
+	transitiveDepsCacheMu.Unlock()
+
+	resetTransitiveDepsCache()
+
+	transitiveDepsCacheMu.Lock()
+	_, ok := transitiveDepsCache[key]
+	transitiveDepsCacheMu.Unlock()
+	if ok {
+		t.Fatal("expected resetTransitiveDepsCache to clear previously cached entries")
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Google Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package android
+
+// This file is the proof-of-concept migration called for when the Provider API was added:
+// visibility used to flow from RegisterVisibilityRuleGatherer to RegisterVisibilityRuleEnforcer
+// through a package-global map keyed by module name, with nothing stopping the enforcer from
+// reading it before the gatherer had run for a given module. Publishing the gathered rules as
+// visibilityRuleSetProvider instead gets that ordering guarantee from the Provider subsystem
+// itself instead of from convention.
+
+// VisibilityRuleSet is the visibility rules gathered for a single module by
+// RegisterVisibilityRuleGatherer, published as visibilityRuleSetProvider and read back by
+// RegisterVisibilityRuleEnforcer.
+type VisibilityRuleSet struct {
+	Rules []string
+}
+
+var visibilityRuleSetProvider = NewProvider(VisibilityRuleSet{})
+
+const (
+	visibilityPublic  = "//visibility:public"
+	visibilityPrivate = "//visibility:private"
+)
+
+// RegisterVisibilityRuleGatherer registers the mutator that reads each module's visibility
+// property and publishes it as visibilityRuleSetProvider. It must run after the defaults
+// mutators, as documented where it's registered in preArch, so that visibility supplied through a
+// defaults module has already been applied before it's gathered.
+func RegisterVisibilityRuleGatherer(ctx RegisterMutatorsContext) {
+	ctx.BottomUp("visibility_rule_gatherer", visibilityRuleGathererMutator)
+	ctx.DeclareProvider(visibilityRuleSetProvider)
+}
+
+func visibilityRuleGathererMutator(ctx BottomUpMutatorContext) {
+	rules := ctx.Module().base().commonProperties.Visibility
+	if len(rules) == 0 {
+		return
+	}
+	ctx.SetProvider(visibilityRuleSetProvider, VisibilityRuleSet{Rules: append([]string(nil), rules...)})
+}
+
+// RegisterVisibilityRuleEnforcer registers the mutator that checks each module's dependencies
+// against the visibility rules RegisterVisibilityRuleGatherer published for them. It must run in
+// PostDepsMutators, after every dependency edge for the tree has been added, and after
+// RegisterVisibilityRuleGatherer has run for every module.
+func RegisterVisibilityRuleEnforcer(ctx RegisterMutatorsContext) {
+	ctx.BottomUp("visibility_rule_enforcer", visibilityRuleEnforcerMutator)
+}
+
+func visibilityRuleEnforcerMutator(ctx BottomUpMutatorContext) {
+	ctx.VisitDirectDeps(func(dep Module) {
+		ruleSet, ok := ctx.Provider(dep, visibilityRuleSetProvider)
+		if !ok {
+			// No rules were gathered for dep, so it has no visibility restriction.
+			return
+		}
+		if !visibilityRuleSetAllows(ruleSet.(VisibilityRuleSet), ctx.ModuleName()) {
+			ctx.ModuleErrorf("depends on %q which is not visible to this module", dep.Name())
+		}
+	})
+}
+
+// visibilityRuleSetAllows reports whether ruleSet permits a module named moduleName to depend on
+// the module it was gathered for.
+func visibilityRuleSetAllows(ruleSet VisibilityRuleSet, moduleName string) bool {
+	for _, rule := range ruleSet.Rules {
+		switch rule {
+		case visibilityPublic:
+			return true
+		case visibilityPrivate:
+			// A private rule only ever grants access to the module's own package, which this
+			// proof-of-concept has no package/directory information to check; it never matches
+			// another module by name.
+		default:
+			if rule == moduleName {
+				return true
+			}
+		}
+	}
+	return false
+}